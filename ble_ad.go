@@ -7,49 +7,130 @@ import (
 	"strings"
 )
 
-// extractServiceData16 finds AD type 0x16 (Service Data - 16-bit UUID).
-// Returns UUID text (e.g., "FEAB"), frameType, remaining service data bytes, ok.
-func extractServiceData16(payloadHex string) (uuidText string, frameType byte, rest []byte, ok bool) {
+// AD type codes we recognize while walking a BLE advertisement. Anything
+// else is still captured in AdStructure.Type/Data, just not given a name
+// here.
+const (
+	AdFlags             byte = 0x01
+	AdIncomplete16      byte = 0x02
+	AdComplete16        byte = 0x03
+	AdIncomplete128     byte = 0x06
+	AdComplete128       byte = 0x07
+	AdShortLocalName    byte = 0x08
+	AdCompleteLocalName byte = 0x09
+	AdTxPowerLevel      byte = 0x0A
+	AdServiceData16     byte = 0x16
+	AdServiceData32     byte = 0x20
+	AdServiceData128    byte = 0x21
+	AdManufacturerData  byte = 0xFF
+)
+
+// AdStructure is one length-type-value record from a BLE advertisement.
+type AdStructure struct {
+	Type byte
+	Data []byte // value bytes only (type byte stripped)
+}
+
+// ParseAdvertisement walks every AD structure in a raw advertisement payload
+// (hex-encoded) and returns them in order. It replaces the old
+// extractServiceData16, which discarded everything but the first 0x16
+// record.
+func ParseAdvertisement(payloadHex string) ([]AdStructure, error) {
 	raw, err := hex.DecodeString(strings.TrimSpace(payloadHex))
-	if err != nil || len(raw) == 0 {
-		return "", 0, nil, false
+	if err != nil {
+		return nil, fmt.Errorf("decode hex payload: %w", err)
 	}
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("empty payload")
+	}
+
+	var out []AdStructure
 	i := 0
 	adIdx := 0
 	for i < len(raw) {
 		if i+1 >= len(raw) {
-			return "", 0, nil, false
+			return nil, fmt.Errorf("AD %d truncated at i=%d total=%d", adIdx, i, len(raw))
 		}
 		length := int(raw[i]) // includes type + data
 		if length == 0 || i+1+length > len(raw) {
-			log.Printf("AD %d malformed length=%d at i=%d total=%d", adIdx, length, i, len(raw))
-			return "", 0, nil, false
+			return nil, fmt.Errorf("AD %d malformed length=%d at i=%d total=%d", adIdx, length, i, len(raw))
 		}
 		typ := raw[i+1]
-		// Debug each AD structure
+		data := raw[i+2 : i+1+length]
 		log.Printf("AD %d len=%d type=0x%02X", adIdx, length, typ)
-		if typ == 0x16 {
-			if length < 4 {
-				log.Printf("AD %d type=0x16 too short length=%d", adIdx, length)
-				return "", 0, nil, false
-			}
-			lsb := raw[i+2] // least significant byte first in AD
-			msb := raw[i+3]
-			uuidText = strings.ToUpper(fmt.Sprintf("%02X%02X", msb, lsb))
-			data := raw[i+4 : i+1+length]
-			if len(data) == 0 {
-				return uuidText, 0, nil, true
-			}
-			frameType = data[0]
-			if len(data) > 1 {
-				rest = data[1:]
-			} else {
-				rest = nil
-			}
-			return uuidText, frameType, rest, true
-		}
+		out = append(out, AdStructure{Type: typ, Data: append([]byte(nil), data...)})
 		i += 1 + length
 		adIdx++
 	}
+	return out, nil
+}
+
+// FindServiceData16 returns the first 0x16 (Service Data - 16-bit UUID)
+// record, split into its UUID text (e.g. "FEAB"), the vendor frame type
+// byte, and the remaining frame bytes.
+func FindServiceData16(ads []AdStructure) (uuidText string, frameType byte, rest []byte, ok bool) {
+	for _, ad := range ads {
+		if ad.Type != AdServiceData16 || len(ad.Data) < 2 {
+			continue
+		}
+		lsb, msb := ad.Data[0], ad.Data[1] // little-endian in the AD record
+		uuidText = strings.ToUpper(fmt.Sprintf("%02X%02X", msb, lsb))
+		body := ad.Data[2:]
+		if len(body) == 0 {
+			return uuidText, 0, nil, true
+		}
+		return uuidText, body[0], body[1:], true
+	}
 	return "", 0, nil, false
 }
+
+// FindManufacturerData returns the first 0xFF (Manufacturer Specific Data)
+// record, split into its company identifier and the remaining vendor bytes.
+func FindManufacturerData(ads []AdStructure) (manufacturerID uint16, rest []byte, ok bool) {
+	for _, ad := range ads {
+		if ad.Type != AdManufacturerData || len(ad.Data) < 2 {
+			continue
+		}
+		manufacturerID = uint16(ad.Data[1])<<8 | uint16(ad.Data[0]) // little-endian company ID
+		return manufacturerID, ad.Data[2:], true
+	}
+	return 0, nil, false
+}
+
+// FindFlags returns the 0x01 Flags byte, if present.
+func FindFlags(ads []AdStructure) (byte, bool) {
+	for _, ad := range ads {
+		if ad.Type == AdFlags && len(ad.Data) >= 1 {
+			return ad.Data[0], true
+		}
+	}
+	return 0, false
+}
+
+// FindTxPower returns the 0x0A TX Power Level, if present.
+func FindTxPower(ads []AdStructure) (int8, bool) {
+	for _, ad := range ads {
+		if ad.Type == AdTxPowerLevel && len(ad.Data) >= 1 {
+			return int8(ad.Data[0]), true
+		}
+	}
+	return 0, false
+}
+
+// FindLocalName returns the device's advertised local name, preferring the
+// complete name (0x09) over the shortened one (0x08).
+func FindLocalName(ads []AdStructure) (string, bool) {
+	var short string
+	for _, ad := range ads {
+		switch ad.Type {
+		case AdCompleteLocalName:
+			return string(ad.Data), true
+		case AdShortLocalName:
+			short = string(ad.Data)
+		}
+	}
+	if short != "" {
+		return short, true
+	}
+	return "", false
+}
@@ -67,6 +67,18 @@ func closePubSub() {
 	}
 }
 
+// resumeOrdering clears the pause that pubsub/v2 places on an ordering key
+// after any publish error for it. Without this, the first transient publish
+// failure for a device would wedge that device's ordering key forever: every
+// later publish would fail immediately with ErrPublishingPaused instead of
+// actually retrying.
+func resumeOrdering(deviceID string) {
+	if !orderingOn || cbPublisher == nil {
+		return
+	}
+	cbPublisher.ResumePublish(strings.ToUpper(deviceID))
+}
+
 func publishCallback(ctx context.Context, evt CallbackEvent) error {
 	if evt.Timestamp == 0 {
 		evt.Timestamp = time.Now().UnixMilli()
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net"
@@ -76,11 +75,13 @@ func macHexToBytea(s string) ([]byte, error) {
 	return b, nil
 }
 
-func fetchGateway(mac string) (name, hwType, clientID string) {
+// fetchGatewayDB is the uncached round trip to Postgres; fetchGateway (in
+// db_cache.go) is what callers should use.
+func fetchGatewayDB(mac string) (name, hwType, clientID string) {
 	ctx := context.Background()
 	bmac, err := macHexToBytea(mac)
 	if err != nil {
-		log.Printf("fetchGateway: %v", err)
+		log.Printf("fetchGatewayDB: %v", err)
 		return
 	}
 	row := pg.QueryRow(ctx,
@@ -91,11 +92,13 @@ func fetchGateway(mac string) (name, hwType, clientID string) {
 	return
 }
 
-func fetchDevice(mac string) (name, deviceID, hwType string) {
+// fetchDeviceDB is the uncached round trip to Postgres; fetchDevice (in
+// db_cache.go) is what callers should use.
+func fetchDeviceDB(mac string) (name, deviceID, hwType string) {
 	ctx := context.Background()
 	bmac, err := macHexToBytea(mac)
 	if err != nil {
-		log.Printf("fetchDevice: %v", err)
+		log.Printf("fetchDeviceDB: %v", err)
 		return
 	}
 	row := pg.QueryRow(ctx,
@@ -105,20 +108,3 @@ func fetchDevice(mac string) (name, deviceID, hwType string) {
 	_ = row.Scan(&name, &deviceID, &hwType)
 	return
 }
-
-// Update parsed_json for the existing backend_message row (id == message_id)
-func updateParsedJSON(backendID int64, v any) error {
-	ctx := context.Background()
-	b, err := json.Marshal(v)
-	if err != nil {
-		return err
-	}
-	ct, err := pg.Exec(ctx, `UPDATE backend_message SET parser_json = $2 WHERE id = $1`, backendID, b)
-	if err != nil {
-		return err
-	}
-	if ct.RowsAffected() == 0 {
-		return fmt.Errorf("no backend_message row found for id=%d", backendID)
-	}
-	return nil
-}
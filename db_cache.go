@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+// Every /message call used to cost two pg.QueryRow round trips even though
+// gateway/device rows change rarely. These caches keep hot MACs local, with
+// a short negative TTL so an unknown-device storm doesn't hammer Postgres
+// either.
+const (
+	cachePositiveTTL = 5 * time.Minute
+	cacheNegativeTTL = 30 * time.Second
+	cacheSize        = 10_000
+)
+
+type gatewayInfo struct {
+	name, hwType, clientID string
+}
+
+type deviceInfo struct {
+	name, deviceID, hwType string
+}
+
+var (
+	gatewayPosCache *lru.LRU[string, gatewayInfo]
+	gatewayNegCache *lru.LRU[string, struct{}]
+	devicePosCache  *lru.LRU[string, deviceInfo]
+	deviceNegCache  *lru.LRU[string, struct{}]
+)
+
+func init() {
+	gatewayPosCache = lru.NewLRU[string, gatewayInfo](cacheSize, nil, cachePositiveTTL)
+	gatewayNegCache = lru.NewLRU[string, struct{}](cacheSize, nil, cacheNegativeTTL)
+	devicePosCache = lru.NewLRU[string, deviceInfo](cacheSize, nil, cachePositiveTTL)
+	deviceNegCache = lru.NewLRU[string, struct{}](cacheSize, nil, cacheNegativeTTL)
+}
+
+func fetchGateway(mac string) (name, hwType, clientID string) {
+	key := strings.ToUpper(mac)
+	if info, ok := gatewayPosCache.Get(key); ok {
+		cacheHits.WithLabelValues("gateway", "positive").Inc()
+		return info.name, info.hwType, info.clientID
+	}
+	if _, ok := gatewayNegCache.Get(key); ok {
+		cacheHits.WithLabelValues("gateway", "negative").Inc()
+		return "", "", ""
+	}
+
+	cacheMisses.WithLabelValues("gateway").Inc()
+	name, hwType, clientID = fetchGatewayDB(mac)
+	if hwType == "" && name == "" {
+		gatewayNegCache.Add(key, struct{}{})
+		return
+	}
+	gatewayPosCache.Add(key, gatewayInfo{name, hwType, clientID})
+	return
+}
+
+func fetchDevice(mac string) (name, deviceID, hwType string) {
+	key := strings.ToUpper(mac)
+	if info, ok := devicePosCache.Get(key); ok {
+		cacheHits.WithLabelValues("device", "positive").Inc()
+		return info.name, info.deviceID, info.hwType
+	}
+	if _, ok := deviceNegCache.Get(key); ok {
+		cacheHits.WithLabelValues("device", "negative").Inc()
+		return "", "", ""
+	}
+
+	cacheMisses.WithLabelValues("device").Inc()
+	name, deviceID, hwType = fetchDeviceDB(mac)
+	if hwType == "" {
+		deviceNegCache.Add(key, struct{}{})
+		return
+	}
+	devicePosCache.Add(key, deviceInfo{name, deviceID, hwType})
+	return
+}
+
+func invalidateGateway(mac string) {
+	key := strings.ToUpper(mac)
+	gatewayPosCache.Remove(key)
+	gatewayNegCache.Remove(key)
+}
+
+func invalidateDevice(mac string) {
+	key := strings.ToUpper(mac)
+	devicePosCache.Remove(key)
+	deviceNegCache.Remove(key)
+}
+
+// startCacheInvalidationListener LISTENs on gateway_changed/device_changed
+// and evicts the affected MAC as soon as a row is updated elsewhere,
+// instead of waiting out the positive TTL. Each NOTIFY payload is the
+// hex/colon MAC of the changed row.
+func startCacheInvalidationListener(ctx context.Context) {
+	go func() {
+		for {
+			if err := runCacheInvalidationListener(ctx); err != nil {
+				log.Printf("CACHE listener error: %v; retrying in 5s", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}()
+}
+
+func runCacheInvalidationListener(ctx context.Context) error {
+	conn, err := pg.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "LISTEN gateway_changed"); err != nil {
+		return err
+	}
+	if _, err := conn.Exec(ctx, "LISTEN device_changed"); err != nil {
+		return err
+	}
+	log.Println("CACHE invalidation listener started")
+
+	for {
+		notif, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		// Cache keys are separator-free, so strip colons/dashes first.
+		mac := strings.NewReplacer(":", "", "-", "").Replace(notif.Payload)
+		switch notif.Channel {
+		case "gateway_changed":
+			invalidateGateway(mac)
+		case "device_changed":
+			invalidateDevice(mac)
+		}
+		log.Printf("CACHE invalidated channel=%s mac=%s", notif.Channel, notif.Payload)
+	}
+}
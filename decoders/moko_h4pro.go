@@ -7,6 +7,10 @@ import (
 	"strings"
 )
 
+func init() {
+	Register("H4 Pro", "FEAB", []byte{0x70, 0x40}, ParseH4ProToOutput)
+}
+
 // Exported (capital P) and no dependency on main package types:
 func ParseH4ProToOutput(frameType byte, p []byte, ts int64, mac, deviceName string, rssi *int) (map[string]any, error) {
 	switch frameType {
@@ -0,0 +1,94 @@
+package decoders
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"ble-parser/schemas"
+)
+
+// Fixture hex payloads are the frame bytes *after* the leading frame_type
+// byte (0x70/0x40), matching what ParseH4ProToOutput receives as p — the
+// same slice FindServiceData16 hands the registry in main.go.
+func TestParseH4ProToOutput(t *testing.T) {
+	rssi := -62
+	const ts int64 = 1690000000000
+
+	cases := []struct {
+		name      string
+		frameType byte
+		payload   string // hex
+		want      map[string]any
+	}{
+		{
+			name:      "t&h",
+			frameType: 0x70,
+			// ranging=00 adv=0A temp=00D7 humidity=025B batt=0BB8 device_type=01 mac=AABBCCDDEEFF
+			payload: "000A00D7025B0BB801AABBCCDDEEFF",
+			want: map[string]any{
+				"message_type":       "h4pro-t&h",
+				"timestamp":          ts,
+				"mac":                "AABBCCDDEEFF",
+				"device_name":        "Sensor1",
+				"rssi":               -62,
+				"adv_interval_steps": 10,
+				"adv_interval_ms":    1000,
+				"temperature":        float64(215) / 10.0,
+				"humidity":           float64(603) / 10.0,
+				"batt_vol":           3000,
+				"device_type":        1,
+			},
+		},
+		{
+			name:      "info",
+			frameType: 0x40,
+			// ranging=00 adv=05 batt=0B54 device_prop=03 switch_status=01 mac=112233445566 firmware=000C
+			payload: "00050B540301112233445566000C",
+			want: map[string]any{
+				"message_type":       "h4pro-info",
+				"timestamp":          ts,
+				"mac":                "AABBCCDDEEFF",
+				"device_name":        "Sensor1",
+				"rssi":               -62,
+				"adv_interval_steps": 5,
+				"adv_interval_ms":    500,
+				"batt_vol":           2900,
+				"device_prop":        3,
+				"device_prop_bits":   "00000011",
+				"switch_status":      1,
+				"switch_status_bits": "00000001",
+				"firmware_ver":       "V0.0.12",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := hex.DecodeString(tc.payload)
+			if err != nil {
+				t.Fatalf("bad fixture hex: %v", err)
+			}
+
+			out, err := ParseH4ProToOutput(tc.frameType, p, ts, "aabbccddeeff", "Sensor1", &rssi)
+			if err != nil {
+				t.Fatalf("ParseH4ProToOutput: %v", err)
+			}
+
+			for k, want := range tc.want {
+				got, ok := out[k]
+				if !ok {
+					t.Errorf("field %q missing from output", k)
+					continue
+				}
+				if got != want {
+					t.Errorf("field %q = %v (%T), want %v (%T)", k, got, got, want, want)
+				}
+			}
+
+			messageType, _ := tc.want["message_type"].(string)
+			if _, err := schemas.Validate(messageType, out); err != nil {
+				t.Errorf("schema validation failed for %s: %v", messageType, err)
+			}
+		})
+	}
+}
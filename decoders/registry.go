@@ -0,0 +1,85 @@
+package decoders
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DecodeFunc decodes a single advertisement frame into the parsed output map.
+// It matches the signature vendors like moko_h4pro.go already export, so
+// existing decoders register without any change to their bodies.
+type DecodeFunc func(frameType byte, p []byte, ts int64, mac, deviceName string, rssi *int) (map[string]any, error)
+
+// Registration describes one (device_hw_type, service UUID) decoder binding,
+// including the frame types it knows how to parse, for operator visibility.
+type Registration struct {
+	HWType     string
+	UUID       string
+	FrameTypes []byte
+	Fn         DecodeFunc
+}
+
+var registry = map[string]Registration{}
+
+func regKey(hwType, uuid string) string {
+	return strings.ToUpper(hwType) + "|" + strings.ToUpper(uuid)
+}
+
+// Register binds a decoder to a device_hw_type + service UUID pair. Call it
+// from an init() in the decoder's own file so adding a new vendor never
+// touches the dispatch hot path in main.go.
+func Register(hwType, uuid string, frameTypes []byte, fn DecodeFunc) {
+	registry[regKey(hwType, uuid)] = Registration{
+		HWType:     hwType,
+		UUID:       strings.ToUpper(uuid),
+		FrameTypes: frameTypes,
+		Fn:         fn,
+	}
+}
+
+// Lookup returns the decoder registered for hwType+uuid, if any.
+func Lookup(hwType, uuid string) (DecodeFunc, bool) {
+	r, ok := registry[regKey(hwType, uuid)]
+	if !ok {
+		return nil, false
+	}
+	return r.Fn, true
+}
+
+// mfgKey formats a manufacturer ID the same way a service UUID is stored,
+// so manufacturer-keyed decoders (iBeacon, Eddystone-adjacent vendor
+// frames, ...) share the same registry and /decoders listing as
+// service-UUID-keyed ones.
+func mfgKey(manufacturerID uint16) string {
+	return fmt.Sprintf("MFG:%04X", manufacturerID)
+}
+
+// RegisterManufacturer binds a decoder to a device_hw_type + manufacturer ID
+// pair, for frames carried in AD type 0xFF (Manufacturer Specific Data)
+// rather than 0x16 service data.
+func RegisterManufacturer(hwType string, manufacturerID uint16, frameTypes []byte, fn DecodeFunc) {
+	Register(hwType, mfgKey(manufacturerID), frameTypes, fn)
+}
+
+// LookupManufacturer returns the decoder registered for hwType + manufacturer
+// ID, if any.
+func LookupManufacturer(hwType string, manufacturerID uint16) (DecodeFunc, bool) {
+	return Lookup(hwType, mfgKey(manufacturerID))
+}
+
+// List returns all registrations sorted by hw_type then uuid, for the
+// /decoders audit endpoint.
+func List() []Registration {
+	out := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].HWType != out[j].HWType {
+			return out[i].HWType < out[j].HWType
+		}
+		return out[i].UUID < out[j].UUID
+	})
+	return out
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+
+	pb "ble-parser/pb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// bleParserServer implements pb.BleParserServer, feeding every message on
+// the stream through the same processMessage pipeline as POST /message.
+type bleParserServer struct {
+	pb.UnimplementedBleParserServer
+}
+
+// startGRPCServer listens on GRPC_PORT (default 9090) and serves
+// BleParser.StreamMessages alongside the existing HTTP mux.
+func startGRPCServer() (*grpc.Server, error) {
+	addr := ":" + getenv("GRPC_PORT", "9090")
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("grpc listen %s: %w", addr, err)
+	}
+
+	srv := grpc.NewServer()
+	pb.RegisterBleParserServer(srv, &bleParserServer{})
+
+	go func() {
+		log.Printf("grpc listening on %s", addr)
+		if err := srv.Serve(lis); err != nil && err != grpc.ErrServerStopped {
+			log.Fatalf("grpc Serve: %v", err)
+		}
+	}()
+
+	return srv, nil
+}
+
+func (s *bleParserServer) StreamMessages(stream grpc.BidiStreamingServer[pb.MQTTMessage, pb.ParseAck]) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(handleStreamMessage(req)); err != nil {
+			return err
+		}
+	}
+}
+
+// handleStreamMessage adapts one pb.MQTTMessage into the same MQTTMessage
+// shape processMessage already knows how to parse and persist, and reports
+// the outcome as a pb.ParseAck.
+func handleStreamMessage(req *pb.MQTTMessage) *pb.ParseAck {
+	trace := genTraceID()
+
+	in := MQTTMessage{
+		MessageID:  req.GetMessageId(),
+		GatewayMAC: req.GetGatewayMac(),
+		GatewayHW:  req.GetGatewayHw(),
+		DeviceMAC:  req.GetDeviceMac(),
+		Payload:    req.GetPayload(),
+		QoS:        int(req.GetQos()),
+		Timestamp:  req.GetTimestamp(),
+	}
+	if req.Rssi != nil {
+		v := int(*req.Rssi)
+		in.RSSI = &v
+	}
+
+	out, devHW, frameType, err := processMessage(trace, &in)
+	if err != nil {
+		log.Printf("GRPC %s error: %v", trace, err)
+		return &pb.ParseAck{MessageId: in.MessageID, Ok: false, Error: err.Error()}
+	}
+
+	parsedStruct, serr := structpb.NewStruct(out)
+	if serr != nil {
+		log.Printf("GRPC %s struct convert error: %v", trace, serr)
+		return &pb.ParseAck{MessageId: in.MessageID, Ok: false, Error: serr.Error()}
+	}
+
+	return &pb.ParseAck{
+		MessageId: in.MessageID,
+		Ok:        true,
+		Result: &pb.ParseResult{
+			ParsedJson:   parsedStruct,
+			FrameType:    fmt.Sprintf("0x%02X", frameType),
+			DeviceHwType: devHW,
+		},
+	}
+}
@@ -9,11 +9,13 @@ import (
 	"math/rand/v2"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
 
 	dec "ble-parser/decoders" // <— ADD: import your subpackage
+	"ble-parser/schemas"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -45,12 +47,24 @@ func main() {
 	}
 	defer closePubSub()
 
+	startOutboxWorker(ctx)
+	startCacheInvalidationListener(ctx)
+
+	grpcSrv, err := startGRPCServer()
+	if err != nil {
+		log.Fatalf("startGRPCServer: %v", err)
+	}
+	defer grpcSrv.GracefulStop()
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("parser ok"))
 	})
 	mux.HandleFunc("/message", handleMessage)
+	mux.HandleFunc("/decoders", handleDecoders)
+	mux.HandleFunc("/replay", handleReplay)
+	mux.HandleFunc("/metrics", handleMetrics)
 	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("parser"))
@@ -79,14 +93,50 @@ func handleMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	normalize(&in)
+	_, devHW, frameType, err := processMessage(trace, &in)
+	if err != nil {
+		status := http.StatusBadRequest
+		if pe, ok := err.(*procError); ok {
+			status = pe.status
+		}
+		log.Printf("MSG %s error: %v", trace, err)
+		http.Error(w, err.Error(), status)
+		return
+	}
+	parseDuration.WithLabelValues(devHW, fmt.Sprintf("0x%02X", frameType)).Observe(time.Since(start).Seconds())
+
+	elapsed := time.Since(start).Milliseconds()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"status":         "ok",
+		"message_id":     in.MessageID,
+		"device_hw_type": devHW,
+		"frame_type":     fmt.Sprintf("0x%02X", frameType),
+		"ms":             elapsed,
+	})
+}
+
+// procError carries the HTTP status a processMessage failure should surface
+// as, while still being a plain error for non-HTTP callers (the gRPC path).
+type procError struct {
+	status int
+	err    error
+}
+
+func (e *procError) Error() string { return e.err.Error() }
+func (e *procError) Unwrap() error { return e.err }
+
+// processMessage runs the full parse pipeline — normalize, validate, gateway
+// + device enrichment, AD walk, decoder dispatch, and the atomic parsed_json
+// + outbox write — shared by both the HTTP /message handler and the gRPC
+// StreamMessages handler.
+func processMessage(trace string, in *MQTTMessage) (out map[string]any, devHW string, frameType byte, err error) {
+	normalize(in)
 	log.Printf("MSG %s recv msg_id=%d gw_mac=%s gw_hw=%s dev_mac=%s qos=%d ts=%d rssi=%v",
 		trace, in.MessageID, in.GatewayMAC, in.GatewayHW, in.DeviceMAC, in.QoS, in.Timestamp, ptrIntStr(in.RSSI))
 
-	if err := validate(&in); err != nil {
-		log.Printf("MSG %s validation error: %v", trace, err)
-		http.Error(w, "validation: "+err.Error(), http.StatusBadRequest)
-		return
+	if verr := validate(in); verr != nil {
+		return nil, "", 0, &procError{http.StatusBadRequest, fmt.Errorf("validation: %w", verr)}
 	}
 
 	// Quick payload preview for logs
@@ -94,10 +144,8 @@ func handleMessage(w http.ResponseWriter, r *http.Request) {
 	log.Printf("MSG %s payload.len=%d preview=%q", trace, len(pl), head(pl, getenvInt("LOG_PAYLOAD_PREVIEW_CHARS", 32)))
 
 	// Validate hex
-	if _, err := hex.DecodeString(pl); err != nil {
-		log.Printf("MSG %s invalid hex payload: %v", trace, err)
-		http.Error(w, "payload must be hex: "+err.Error(), http.StatusBadRequest)
-		return
+	if _, herr := hex.DecodeString(pl); herr != nil {
+		return nil, "", 0, &procError{http.StatusBadRequest, fmt.Errorf("payload must be hex: %w", herr)}
 	}
 
 	// 2) Gateway enrichment (not used yet → assign to _ to avoid compile error)
@@ -112,100 +160,197 @@ func handleMessage(w http.ResponseWriter, r *http.Request) {
 	devName, _, devHW := fetchDevice(in.DeviceMAC)
 	if devHW == "" {
 		log.Printf("MSG %s device not found dev_mac=%s", trace, in.DeviceMAC)
-		http.Error(w, "unknown device_hw_type; device not found", http.StatusBadRequest)
-		return
+		return nil, "", 0, &procError{http.StatusBadRequest, fmt.Errorf("unknown device_hw_type; device not found")}
 	}
 	log.Printf("MSG %s device ok name=%q hw=%q", trace, devName, devHW)
 
-	// 4) Extract Service Data (must be 0x16 with UUID FEAB)
-	uuidText, frameType, rest, ok := extractServiceData16(in.Payload)
-	if !ok {
-		log.Printf("MSG %s no 0x16 service data in ADV", trace)
-		http.Error(w, "no 0x16 service data in advertisement", http.StatusBadRequest)
-		return
+	// 4) Walk every AD structure in the advertisement, not just the first 0x16
+	ads, aerr := ParseAdvertisement(in.Payload)
+	if aerr != nil {
+		log.Printf("MSG %s advertisement parse error: %v", trace, aerr)
+		return nil, "", 0, &procError{http.StatusBadRequest, fmt.Errorf("advertisement parse error: %w", aerr)}
 	}
 
-	if strings.ToUpper(uuidText) != "FEAB" {
-		msg := fmt.Sprintf("unsupported service uuid %s (need FEAB)", uuidText)
+	adMeta := map[string]any{}
+	if flags, ok := FindFlags(ads); ok {
+		adMeta["flags"] = fmt.Sprintf("0x%02X", flags)
+	}
+	if tx, ok := FindTxPower(ads); ok {
+		adMeta["tx_power"] = int(tx)
+	}
+	if name, ok := FindLocalName(ads); ok {
+		adMeta["local_name"] = name
+	}
+	manufacturerID, mfgRest, mfgOK := FindManufacturerData(ads)
+	if mfgOK {
+		adMeta["manufacturer_id"] = fmt.Sprintf("0x%04X", manufacturerID)
+	}
+
+	// 5) Find a frame to decode: prefer 0x16 service data, fall back to 0xFF
+	// manufacturer data so iBeacon / Eddystone / vendor manufacturer-specific
+	// frames route through the same registry.
+	var (
+		rest        []byte
+		decodeFn    dec.DecodeFunc
+		found       bool
+		sourceLabel string
+	)
+	uuidText, sdFrameType, sdRest, sdOK := FindServiceData16(ads)
+	if sdOK {
+		if fn, ok := dec.Lookup(devHW, uuidText); ok {
+			decodeFn, found, frameType, rest, sourceLabel = fn, true, sdFrameType, sdRest, strings.ToUpper(uuidText)
+		}
+	}
+	if !found && mfgOK {
+		if fn, ok := dec.LookupManufacturer(devHW, manufacturerID); ok {
+			var mfgFrameType byte
+			if len(mfgRest) > 0 {
+				mfgFrameType = mfgRest[0]
+				mfgRest = mfgRest[1:]
+			}
+			decodeFn, found, frameType, rest, sourceLabel = fn, true, mfgFrameType, mfgRest, fmt.Sprintf("MFG:%04X", manufacturerID)
+		}
+	}
+	if !found {
+		msg := fmt.Sprintf("no decoder registered for device_hw_type=%q (service uuid=%q)", devHW, uuidText)
 		log.Printf("MSG %s %s", trace, msg)
-		http.Error(w, msg, http.StatusBadRequest)
-		return
+		return nil, "", 0, &procError{http.StatusBadRequest, fmt.Errorf("%s", msg)}
 	}
 
-	// 5) Parse by device_hw_type + frame_type
-	var out any
-	var err error
-	switch devHW {
-	case "H4 Pro":
-		// NOTE: pass primitives; subpackage cannot use type from main
-		log.Printf("MSG %s decode enter hw=%q frame=0x%02X", trace, devHW, frameType)
-		out, err = dec.ParseH4ProToOutput(frameType, rest, in.Timestamp, in.DeviceMAC, devName, in.RSSI)
-	default:
-		err = fmt.Errorf("no parser for device_hw_type=%q", devHW)
+	log.Printf("MSG %s decode enter hw=%q source=%s frame=0x%02X", trace, devHW, sourceLabel, frameType)
+	out, derr := decodeFn(frameType, rest, in.Timestamp, in.DeviceMAC, devName, in.RSSI)
+	if derr != nil {
+		log.Printf("MSG %s parse error: %v", trace, derr)
+		return nil, "", 0, &procError{http.StatusBadRequest, fmt.Errorf("parse error: %w", derr)}
 	}
-	if err != nil {
-		log.Printf("MSG %s parse error: %v", trace, err)
-		http.Error(w, "parse error: "+err.Error(), http.StatusBadRequest)
-		return
+	for k, v := range adMeta {
+		if _, exists := out[k]; !exists {
+			out[k] = v
+		}
 	}
 	log.Printf("MSG %s decode ok", trace)
 
-	// 6) Store the output JSON into backend_message.parsed_json
-	if err := updateParsedJSON(in.MessageID, out); err != nil {
+	// 5b) Validate the decoder's output against its message_type's JSON
+	// Schema before it ever reaches parsed_json or a Pub/Sub subscriber.
+	// Quarantines (rejects) frames a drifting decoder produced that no
+	// longer match what downstream consumers expect.
+	msgType, _ := out["message_type"].(string)
+	schemaURI, serr := schemas.Validate(msgType, out)
+	if serr != nil {
+		log.Printf("MSG %s schema validation failed message_type=%q: %v; quarantining frame", trace, msgType, serr)
+		return nil, "", 0, &procError{http.StatusUnprocessableEntity, fmt.Errorf("schema validation: %w", serr)}
+	}
+
+	// 6) Store parsed_json and enqueue its callback atomically. This
+	// replaces the old sequence of updateParsedJSON followed by a
+	// best-effort publishCallback, which could lose the event if the
+	// publish failed after parsed_json had already been committed.
+	var rssiVal any
+	if in.RSSI != nil {
+		rssiVal = *in.RSSI
+	}
+	evt := CallbackEvent{
+		DeviceId:  strings.ToUpper(in.DeviceMAC),
+		Type:      deriveEventType(devHW, frameType, out),
+		Timestamp: in.Timestamp,
+		GatewayID: strings.ToUpper(in.GatewayMAC),
+		Data: map[string]any{
+			"parsed_json":    out,
+			"raw_data":       in.Payload,
+			"source":         sourceLabel,
+			"frame_type":     fmt.Sprintf("0x%02X", frameType),
+			"schema_version": schemas.Version,
+			"schema_uri":     schemaURI,
+		},
+		BackendID: in.MessageID,
+	}
+	if rssiVal != nil {
+		evt.Data["rssi"] = rssiVal
+	}
+
+	if uerr := updateParsedJSONWithOutbox(in.MessageID, out, evt); uerr != nil {
 		var pgErr *pgconn.PgError
-		if ok := errorAs(err, &pgErr); ok {
+		if ok := errorAs(uerr, &pgErr); ok {
 			log.Printf("MSG %s db update error: %s (%s) detail=%s", trace, pgErr.Message, pgErr.Code, pgErr.Detail)
 		} else {
-			log.Printf("MSG %s db update error: %v", trace, err)
+			log.Printf("MSG %s db update error: %v", trace, uerr)
 		}
-		http.Error(w, "db update parsed_json: "+err.Error(), http.StatusInternalServerError)
-		return
+		return nil, "", 0, &procError{http.StatusInternalServerError, fmt.Errorf("db update parsed_json: %w", uerr)}
 	}
-	log.Printf("MSG %s db update ok message_id=%d", trace, in.MessageID)
+	log.Printf("MSG %s db update + outbox enqueue ok message_id=%d", trace, in.MessageID)
 
-	// 7) Publish to Pub/Sub callbacks (if Pub/Sub v2 publisher was initialized)
-	if cbPublisher != nil {
-		// Optional RSSI insertion (in.RSSI is *int)
-		var rssiVal any
-		if in.RSSI != nil {
-			rssiVal = *in.RSSI
-		}
+	return out, devHW, frameType, nil
+}
 
-		evt := CallbackEvent{
-			DeviceId:  strings.ToUpper(in.DeviceMAC),
-			Type:      deriveEventType(devHW, frameType, out),
-			Timestamp: in.Timestamp,
-			GatewayID: strings.ToUpper(in.GatewayMAC),
-			Data: map[string]any{
-				"parsed_json": out,
-				"raw_data":    in.Payload,
-				"uuid":        strings.ToUpper(uuidText),
-				"frame_type":  fmt.Sprintf("0x%02X", frameType),
-			},
-			BackendID: in.MessageID,
-		}
-		if rssiVal != nil {
-			evt.Data["rssi"] = rssiVal
+// handleDecoders lists the (hw_type, uuid, frame_type) tuples currently
+// registered, so operators can audit what the parser knows without reading
+// source.
+func handleDecoders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET", http.StatusMethodNotAllowed)
+		return
+	}
+	regs := dec.List()
+	out := make([]map[string]any, 0, len(regs))
+	for _, reg := range regs {
+		frames := make([]string, 0, len(reg.FrameTypes))
+		for _, ft := range reg.FrameTypes {
+			frames = append(frames, fmt.Sprintf("0x%02X", ft))
 		}
+		out = append(out, map[string]any{
+			"hw_type":     reg.HWType,
+			"uuid":        reg.UUID,
+			"frame_types": frames,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"decoders": out})
+}
 
-		if err := publishCallback(r.Context(), evt); err != nil {
-			log.Printf("MSG %s publishCallback error: %v", trace, err)
-			// non fatal, we already stored parsed_json
-		} else {
-			log.Printf("MSG %s publishCallback ok topic=%s, device=%s", trace, callbackTopic, evt.DeviceId)
+// handleReplay re-queues callback_outbox rows for a backend_message id
+// range, so an operator can re-push callbacks that were delivered before an
+// incident or dead-lettered. Example: POST /replay?from_id=1000&to_id=1050
+func handleReplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST", http.StatusMethodNotAllowed)
+		return
+	}
+	fromStr := r.URL.Query().Get("from_id")
+	if fromStr == "" {
+		http.Error(w, "from_id required", http.StatusBadRequest)
+		return
+	}
+	fromID, err := strconv.ParseInt(fromStr, 10, 64)
+	if err != nil {
+		http.Error(w, "from_id must be an integer", http.StatusBadRequest)
+		return
+	}
+	toID := fromID
+	if toStr := r.URL.Query().Get("to_id"); toStr != "" {
+		toID, err = strconv.ParseInt(toStr, 10, 64)
+		if err != nil {
+			http.Error(w, "to_id must be an integer", http.StatusBadRequest)
+			return
 		}
-	} else {
-		log.Printf("MSG %s pubsub not initialized; skipping publish", trace)
+	}
+	if toID < fromID {
+		http.Error(w, "to_id must be >= from_id", http.StatusBadRequest)
+		return
 	}
 
-	elapsed := time.Since(start).Milliseconds()
+	n, err := replayOutboxRange(r.Context(), fromID, toID)
+	if err != nil {
+		log.Printf("REPLAY from_id=%d to_id=%d error: %v", fromID, toID, err)
+		http.Error(w, "replay error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("REPLAY from_id=%d to_id=%d requeued=%d", fromID, toID, n)
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
-		"status":         "ok",
-		"message_id":     in.MessageID,
-		"device_hw_type": devHW,
-		"frame_type":     fmt.Sprintf("0x%02X", frameType),
-		"ms":             elapsed,
+		"status":   "ok",
+		"from_id":  fromID,
+		"to_id":    toID,
+		"requeued": n,
 	})
 }
 
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics exposed on /metrics for operators: cache effectiveness, parse
+// latency by device/frame so a slow decoder shows up immediately, and
+// callback publish failures so a Pub/Sub outage is visible before the
+// outbox dead-letters anything.
+var (
+	cacheHits = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "parser_db_cache_hits_total",
+		Help: "Gateway/device lookups served from cache, by table and hit kind (positive/negative).",
+	}, []string{"table", "kind"})
+
+	cacheMisses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "parser_db_cache_misses_total",
+		Help: "Gateway/device lookups that missed both the positive and negative cache and fell through to Postgres, by table.",
+	}, []string{"table"})
+
+	parseDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "parser_parse_duration_seconds",
+		Help:    "Time to run the full parse pipeline for one /message request.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"device_hw_type", "frame_type"})
+
+	publishFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "parser_publish_failures_total",
+		Help: "Outbox deliveries that failed on a publishCallback attempt (retried or dead-lettered).",
+	})
+)
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	promhttp.Handler().ServeHTTP(w, r)
+}
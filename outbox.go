@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// callback_outbox / callback_dead_letter give the parsed_json write and the
+// Pub/Sub publish at-least-once, atomic delivery: the outbox row commits in
+// the same transaction as parser_json, so a Pub/Sub outage after a
+// successful parse can never silently drop the event the way the old
+// best-effort publish-after-commit did.
+const (
+	outboxMaxAttempts   = 8
+	outboxBaseDelay     = 2 * time.Second
+	outboxMaxDelay      = 5 * time.Minute
+	outboxShardCount    = 16
+	outboxPollInterval  = 500 * time.Millisecond
+	outboxBatchPerShard = 20
+)
+
+// outboxRow mirrors one callback_outbox row.
+type outboxRow struct {
+	ID        int64
+	BackendID int64
+	DeviceID  string
+	GatewayID string
+	EventType string
+	Payload   []byte // JSON-encoded CallbackEvent.Data
+	Timestamp int64
+	Attempts  int
+}
+
+// enqueueOutboxTx inserts a pending callback_outbox row inside an existing
+// transaction, so it commits atomically with the parsed_json write.
+func enqueueOutboxTx(ctx context.Context, tx pgx.Tx, evt CallbackEvent) error {
+	b, err := json.Marshal(evt.Data)
+	if err != nil {
+		return fmt.Errorf("marshal outbox payload: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO callback_outbox
+			(backend_id, device_id, gateway_id, event_type, payload, event_ts, status, attempts, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 'pending', 0, now())`,
+		evt.BackendID, evt.DeviceId, evt.GatewayID, evt.Type, b, evt.Timestamp)
+	if err != nil {
+		return fmt.Errorf("insert callback_outbox: %w", err)
+	}
+	return nil
+}
+
+// updateParsedJSONWithOutbox writes parsed_json and enqueues its callback in
+// one transaction. Replaces the old sequence of updateParsedJSON followed by
+// a best-effort publishCallback.
+func updateParsedJSONWithOutbox(backendID int64, parsedJSON any, evt CallbackEvent) error {
+	ctx := context.Background()
+	b, err := json.Marshal(parsedJSON)
+	if err != nil {
+		return err
+	}
+
+	tx, err := pg.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	ct, err := tx.Exec(ctx, `UPDATE backend_message SET parser_json = $2 WHERE id = $1`, backendID, b)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("no backend_message row found for id=%d", backendID)
+	}
+
+	if err := enqueueOutboxTx(ctx, tx, evt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// startOutboxWorker launches outboxShardCount goroutines, each responsible
+// for a disjoint slice of device MACs (hashed into shards). Publishes for a
+// given device always land in the same shard and that shard drains
+// sequentially, so per-device ordering is preserved without serializing
+// unrelated devices behind a single drain loop.
+func startOutboxWorker(ctx context.Context) {
+	for shard := 0; shard < outboxShardCount; shard++ {
+		go runOutboxShard(ctx, shard)
+	}
+	log.Printf("OUTBOX worker started shards=%d", outboxShardCount)
+}
+
+func runOutboxShard(ctx context.Context, shard int) {
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			drainOutboxShard(ctx, shard)
+		}
+	}
+}
+
+func drainOutboxShard(ctx context.Context, shard int) {
+	rows, err := fetchOutboxBatch(ctx, shard, outboxBatchPerShard)
+	if err != nil {
+		log.Printf("OUTBOX shard=%d fetch error: %v", shard, err)
+		return
+	}
+	for _, row := range rows {
+		deliverOutboxRow(ctx, row)
+	}
+}
+
+// fetchOutboxBatch selects each shard device's oldest pending row, due or
+// not, then keeps only the ones that are actually due. This is what makes
+// ordering real: a device never has more than one row in flight, so a later
+// row can't jump ahead of an earlier one still sitting in its backoff
+// window, and whatever row comes back for a device is always the exact row
+// deliverOutboxRow should resume the ordering key for.
+func fetchOutboxBatch(ctx context.Context, shard, limit int) ([]outboxRow, error) {
+	sqlRows, err := pg.Query(ctx, `
+		SELECT id, backend_id, device_id, gateway_id, event_type, payload, event_ts, attempts
+		FROM (
+			SELECT DISTINCT ON (device_id)
+				id, backend_id, device_id, gateway_id, event_type, payload, event_ts, attempts, next_attempt_at
+			FROM callback_outbox
+			WHERE status = 'pending' AND mod(abs(hashtext(device_id)), $1) = $2
+			ORDER BY device_id, id
+		) oldest_per_device
+		WHERE next_attempt_at <= now()
+		ORDER BY id
+		LIMIT $3`, outboxShardCount, shard, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlRows.Close()
+
+	var out []outboxRow
+	for sqlRows.Next() {
+		var row outboxRow
+		if err := sqlRows.Scan(&row.ID, &row.BackendID, &row.DeviceID, &row.GatewayID, &row.EventType, &row.Payload, &row.Timestamp, &row.Attempts); err != nil {
+			return nil, err
+		}
+		out = append(out, row)
+	}
+	return out, sqlRows.Err()
+}
+
+func deliverOutboxRow(ctx context.Context, row outboxRow) {
+	var data map[string]any
+	if err := json.Unmarshal(row.Payload, &data); err != nil {
+		log.Printf("OUTBOX id=%d unmarshal error: %v; dead-lettering", row.ID, err)
+		deadLetterOutboxRow(ctx, row, err)
+		return
+	}
+
+	evt := CallbackEvent{
+		DeviceId:  row.DeviceID,
+		Type:      row.EventType,
+		Timestamp: row.Timestamp,
+		GatewayID: row.GatewayID,
+		Data:      data,
+		BackendID: row.BackendID,
+	}
+
+	resumeOrdering(row.DeviceID)
+	if err := publishCallback(ctx, evt); err != nil {
+		publishFailures.Inc()
+		attempts := row.Attempts + 1
+		if attempts >= outboxMaxAttempts {
+			log.Printf("OUTBOX id=%d exhausted attempts=%d: %v; dead-lettering", row.ID, attempts, err)
+			deadLetterOutboxRow(ctx, row, err)
+			return
+		}
+		delay := backoffDelay(attempts)
+		if _, uErr := pg.Exec(ctx, `
+			UPDATE callback_outbox
+			SET attempts = $2, last_error = $3, next_attempt_at = now() + $4
+			WHERE id = $1`, row.ID, attempts, err.Error(), delay); uErr != nil {
+			log.Printf("OUTBOX id=%d retry-update error: %v", row.ID, uErr)
+		}
+		return
+	}
+
+	if _, err := pg.Exec(ctx, `UPDATE callback_outbox SET status = 'delivered' WHERE id = $1`, row.ID); err != nil {
+		log.Printf("OUTBOX id=%d mark-delivered error: %v", row.ID, err)
+	}
+}
+
+func deadLetterOutboxRow(ctx context.Context, row outboxRow, cause error) {
+	tx, err := pg.Begin(ctx)
+	if err != nil {
+		log.Printf("OUTBOX id=%d dead-letter begin error: %v", row.ID, err)
+		return
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO callback_dead_letter
+			(outbox_id, backend_id, device_id, gateway_id, event_type, payload, event_ts, attempts, last_error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		row.ID, row.BackendID, row.DeviceID, row.GatewayID, row.EventType, row.Payload, row.Timestamp, row.Attempts+1, cause.Error())
+	if err != nil {
+		log.Printf("OUTBOX id=%d dead-letter insert error: %v", row.ID, err)
+		return
+	}
+	if _, err := tx.Exec(ctx, `UPDATE callback_outbox SET status = 'dead' WHERE id = $1`, row.ID); err != nil {
+		log.Printf("OUTBOX id=%d dead-letter mark error: %v", row.ID, err)
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		log.Printf("OUTBOX id=%d dead-letter commit error: %v", row.ID, err)
+	}
+}
+
+// backoffDelay returns an exponential backoff whose base is capped at
+// outboxMaxDelay, plus up to 25% random jitter on top of that base.
+func backoffDelay(attempts int) time.Duration {
+	d := outboxBaseDelay * time.Duration(1<<uint(attempts))
+	if d <= 0 || d > outboxMaxDelay {
+		d = outboxMaxDelay
+	}
+	jitter := time.Duration(rand.Int64N(int64(d)/4 + 1))
+	return d + jitter
+}
+
+// replayOutboxRange resets delivered/dead/pending outbox rows for
+// backend_message ids in [fromID, toID] back to pending, for the /replay
+// admin endpoint.
+func replayOutboxRange(ctx context.Context, fromID, toID int64) (int64, error) {
+	ct, err := pg.Exec(ctx, `
+		UPDATE callback_outbox
+		SET status = 'pending', attempts = 0, last_error = NULL, next_attempt_at = now()
+		WHERE backend_id BETWEEN $1 AND $2`, fromID, toID)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
@@ -0,0 +1,108 @@
+// Package schemas validates decoder output against a JSON Schema per
+// message_type, so a drifting decoder fails loudly in handleMessage instead
+// of silently shipping a malformed parsed_json to every Pub/Sub subscriber.
+package schemas
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+//go:embed *.schema.json
+var schemaFS embed.FS
+
+// Version is shipped as CallbackEvent.Data["schema_version"] so downstream
+// consumers know which generation of a message_type's schema a payload was
+// validated against. Bump it whenever a schema file changes in a
+// backward-incompatible way.
+const Version = "1"
+
+// compiled holds one *jsonschema.Schema per message_type, keyed by the
+// schema's own filename stem (e.g. "h4pro-t&h").
+var compiled = map[string]*jsonschema.Schema{}
+
+func init() {
+	entries, err := schemaFS.ReadDir(".")
+	if err != nil {
+		panic(fmt.Sprintf("schemas: read embedded dir: %v", err))
+	}
+	c := jsonschema.NewCompiler()
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".schema.json") {
+			continue
+		}
+		b, err := schemaFS.ReadFile(name)
+		if err != nil {
+			panic(fmt.Sprintf("schemas: read %s: %v", name, err))
+		}
+		if err := c.AddResource(name, bytes.NewReader(b)); err != nil {
+			panic(fmt.Sprintf("schemas: add resource %s: %v", name, err))
+		}
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasSuffix(name, ".schema.json") {
+			continue
+		}
+		sch, err := c.Compile(name)
+		if err != nil {
+			panic(fmt.Sprintf("schemas: compile %s: %v", name, err))
+		}
+		messageType := strings.TrimSuffix(name, ".schema.json")
+		compiled[messageType] = sch
+	}
+}
+
+// URI returns the schema_uri ($id) for a message_type, for stamping onto
+// outbound CallbackEvents even when the caller only has the validated doc.
+func URI(messageType string) (string, bool) {
+	sch, ok := compiled[messageType]
+	if !ok {
+		return "", false
+	}
+	return sch.Location, true
+}
+
+// Validate checks doc against the schema registered for messageType. It
+// returns the schema_uri that matched on success; callers with no schema
+// registered for a message_type get ErrNoSchema rather than a silent pass,
+// since an unvalidated message_type is exactly the drift this package
+// exists to catch.
+func Validate(messageType string, doc map[string]any) (schemaURI string, err error) {
+	sch, ok := compiled[messageType]
+	if !ok {
+		return "", ErrNoSchema{MessageType: messageType}
+	}
+	// jsonschema validates decoded JSON values (map[string]any/float64/...),
+	// so round-trip through json to normalize ints/byte slices/etc the way a
+	// real Pub/Sub subscriber would see them.
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("marshal doc for validation: %w", err)
+	}
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return "", fmt.Errorf("unmarshal doc for validation: %w", err)
+	}
+	if err := sch.Validate(v); err != nil {
+		return "", err
+	}
+	return sch.Location, nil
+}
+
+// ErrNoSchema means no schema file is registered for a message_type. Treated
+// as a validation failure by callers: every decoder output must have a
+// schema once it's onboarded through the registry in decoders/registry.go.
+type ErrNoSchema struct {
+	MessageType string
+}
+
+func (e ErrNoSchema) Error() string {
+	return fmt.Sprintf("no schema registered for message_type %q", e.MessageType)
+}